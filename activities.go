@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kballard/go-shellquote"
+	"github.com/rwool/red/events"
+	"github.com/rwool/red/network"
+	"github.com/sirupsen/logrus"
+)
+
+func init() {
+	RegisterActivity("process", newProcessActivity)
+	RegisterActivity("file", newFileActivity)
+	RegisterActivity("network", newNetworkActivity)
+}
+
+// techniqueProcessExecution is the MITRE ATT&CK technique simulated by the
+// process activity.
+const techniqueProcessExecution = "T1059"
+
+// techniqueFileOperation is the MITRE ATT&CK technique simulated by the file
+// activity's create/modify/delete sequence.
+const techniqueFileOperation = "T1005"
+
+// techniqueIngressToolTransfer is the MITRE ATT&CK technique simulated by the
+// network activity's TCP data transfer.
+const techniqueIngressToolTransfer = "T1105"
+
+// networkTechniques maps each network.Transport to the MITRE ATT&CK
+// technique it best simulates.
+var networkTechniques = map[network.Transport]string{
+	network.TCP:   techniqueIngressToolTransfer,
+	network.UDP:   "T1095",     // Non-Application Layer Protocol.
+	network.TLS:   "T1573",     // Encrypted Channel.
+	network.HTTP:  "T1071.001", // Application Layer Protocol: Web Protocols.
+	network.HTTPS: "T1071.001", // Application Layer Protocol: Web Protocols.
+	network.DNS:   "T1071.004", // Application Layer Protocol: DNS.
+	network.ICMP:  "T1095",     // Non-Application Layer Protocol.
+}
+
+// currentUsername returns the current user's name, or "" if it cannot be
+// determined.
+func currentUsername() string {
+	u, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	return u.Username
+}
+
+// processActivity starts a short-lived child process, matching the original
+// hard-coded `echo` invocation.
+type processActivity struct {
+	log  *logrus.Entry
+	emit events.Emitter
+	args []string
+	cmd  *exec.Cmd
+}
+
+func newProcessActivity(log *logrus.Entry, emit events.Emitter, params map[string]interface{}) (Activity, error) {
+	raw, err := paramString(params, "arguments", "")
+	if err != nil {
+		return nil, fmt.Errorf("process activity: %w", err)
+	}
+	args, err := shellquote.Split(raw)
+	if err != nil {
+		return nil, fmt.Errorf("process activity: invalid arguments: %w", err)
+	}
+	return &processActivity{log: log, emit: emit, args: args}, nil
+}
+
+func (a *processActivity) Name() string { return "process" }
+
+func (a *processActivity) Prepare(ctx context.Context) error { return nil }
+
+func (a *processActivity) Run(ctx context.Context) error {
+	a.cmd = exec.CommandContext(ctx, "echo", a.args...)
+	err := a.cmd.Start()
+	if err != nil {
+		errLog(a.log, err, "process start")
+		return fmt.Errorf("error starting the command: %w", err)
+	}
+	if err := a.emit.Emit(events.ProcessEvent{
+		Event:       events.NewEvent(techniqueProcessExecution),
+		PID:         a.cmd.Process.Pid,
+		Name:        a.cmd.Path,
+		CommandLine: strings.Join(a.cmd.Args, " "),
+		User:        currentUsername(),
+	}); err != nil {
+		a.log.WithError(err).Error("emit process event")
+	}
+	if err := a.cmd.Wait(); err != nil {
+		return fmt.Errorf("command error: %w", err)
+	}
+	return nil
+}
+
+func (a *processActivity) Cleanup(ctx context.Context) error { return nil }
+
+// fileActivity creates a file, appends to it, and deletes it, matching the
+// original file create/modify/delete sequence.
+type fileActivity struct {
+	log  *logrus.Entry
+	emit events.Emitter
+	path string
+	f    *os.File
+}
+
+func newFileActivity(log *logrus.Entry, emit events.Emitter, params map[string]interface{}) (Activity, error) {
+	directory, err := paramString(params, "directory", "")
+	if err != nil {
+		return nil, fmt.Errorf("file activity: %w", err)
+	}
+	ext, err := paramString(params, "extension", ".txt")
+	if err != nil {
+		return nil, fmt.Errorf("file activity: %w", err)
+	}
+	if directory == "" {
+		directory = os.TempDir()
+	}
+	directory, err = filepath.Abs(directory)
+	if err != nil {
+		return nil, fmt.Errorf("file activity: unable to get absolute path for directory: %w", err)
+	}
+	r := rand.New(rand.NewSource(time.Now().Unix()))
+	path := filepath.Join(directory, strconv.Itoa(r.Intn(1_000_000))+ext)
+	return &fileActivity{log: log, emit: emit, path: path}, nil
+}
+
+func (a *fileActivity) Name() string { return "file" }
+
+func (a *fileActivity) Prepare(ctx context.Context) error { return nil }
+
+func (a *fileActivity) emitFileEvent(action string) {
+	if err := a.emit.Emit(events.FileEvent{
+		Event:  events.NewEvent(techniqueFileOperation),
+		Path:   a.path,
+		Action: action,
+		User:   currentUsername(),
+	}); err != nil {
+		a.log.WithError(err).Error("emit file event")
+	}
+}
+
+func (a *fileActivity) Run(ctx context.Context) error {
+	f, err := os.Create(a.path)
+	if err != nil {
+		errLog(a.log.WithField("file path", a.path), err, "create file")
+		return fmt.Errorf("unable to create file: %w", err)
+	}
+	a.f = f
+	a.emitFileEvent("creation")
+
+	_, err = f.WriteString("file append")
+	if err != nil {
+		errLog(a.log.WithField("file path", a.path), err, "modify file")
+		return fmt.Errorf("unable to write to string: %w", err)
+	}
+	a.emitFileEvent("change")
+	return nil
+}
+
+func (a *fileActivity) Cleanup(ctx context.Context) error {
+	if a.f == nil {
+		return nil
+	}
+	if err := a.f.Close(); err != nil {
+		a.log.WithError(err).Error("close file")
+	}
+	err := os.Remove(a.f.Name())
+	if err != nil {
+		errLog(a.log.WithField("file path", a.path), err, "delete file")
+		return fmt.Errorf("unable to delete file: %w", err)
+	}
+	a.emitFileEvent("deletion")
+	return nil
+}
+
+// networkActivity sends data over a network connection. By default it
+// matches the original behavior of a loopback TCP transfer, but the
+// "transport" and "destination" params select any network.Transport and, if
+// destination is set, dial it directly via network.RemoteConnect instead of
+// spinning up a loopback listener. If "room" is also set, destination is
+// instead treated as a rendezvous relay address (see the `red relay`
+// subcommand), so this activity pairs up with a matching activity on
+// another host instead of dialing destination itself.
+type networkActivity struct {
+	log             *logrus.Entry
+	emit            events.Emitter
+	transport       network.Transport
+	destination     string
+	room            string
+	rateBytesPerSec int
+	chunkSize       int
+}
+
+func newNetworkActivity(log *logrus.Entry, emit events.Emitter, params map[string]interface{}) (Activity, error) {
+	transport, err := paramString(params, "transport", string(network.TCP))
+	if err != nil {
+		return nil, fmt.Errorf("network activity: %w", err)
+	}
+	destination, err := paramString(params, "destination", "")
+	if err != nil {
+		return nil, fmt.Errorf("network activity: %w", err)
+	}
+	room, err := paramString(params, "room", "")
+	if err != nil {
+		return nil, fmt.Errorf("network activity: %w", err)
+	}
+	if _, ok := networkTechniques[network.Transport(transport)]; !ok {
+		return nil, fmt.Errorf("network activity: unknown transport %q", transport)
+	}
+	if room != "" && network.Transport(transport) != network.TCP {
+		return nil, fmt.Errorf("network activity: room requires the %q transport, got %q", network.TCP, transport)
+	}
+	if room != "" && destination == "" {
+		return nil, fmt.Errorf("network activity: room requires a destination (the relay's address)")
+	}
+	rateBytesPerSec, err := paramInt(params, "rate_bytes_per_sec", 0)
+	if err != nil {
+		return nil, fmt.Errorf("network activity: %w", err)
+	}
+	chunkSize, err := paramInt(params, "chunk_size", 0)
+	if err != nil {
+		return nil, fmt.Errorf("network activity: %w", err)
+	}
+	return &networkActivity{
+		log:             log,
+		emit:            emit,
+		transport:       network.Transport(transport),
+		destination:     destination,
+		room:            room,
+		rateBytesPerSec: rateBytesPerSec,
+		chunkSize:       chunkSize,
+	}, nil
+}
+
+func (a *networkActivity) Name() string { return "network" }
+
+func (a *networkActivity) Prepare(ctx context.Context) error { return nil }
+
+func (a *networkActivity) Run(ctx context.Context) error {
+	spec := network.Spec{
+		Transport:       a.transport,
+		Destination:     a.destination,
+		Room:            a.room,
+		Payload:         strings.NewReader("hello"),
+		RateBytesPerSec: a.rateBytesPerSec,
+		ChunkSize:       a.chunkSize,
+	}
+
+	var result network.Result
+	var err error
+	if a.destination == "" {
+		result, err = network.Connect(ctx, a.log, spec)
+	} else {
+		result, err = network.RemoteConnect(ctx, a.log, spec)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to transmit data over a network connection: %w", err)
+	}
+
+	destIP := a.destination
+	relayAddress := ""
+	switch {
+	case a.room != "":
+		// The relay pairs us with a peer on another host whose address we
+		// never see directly; record the relay we went through instead.
+		relayAddress = a.destination
+		destIP = ""
+	case destIP == "":
+		destIP = "127.0.0.1"
+	}
+	if emitErr := a.emit.Emit(events.NetworkEvent{
+		Event:          events.NewEvent(networkTechniques[a.transport]),
+		SourceIP:       "127.0.0.1",
+		DestinationIP:  destIP,
+		Transport:      string(a.transport),
+		BytesSent:      result.BytesSent,
+		User:           currentUsername(),
+		RelayAddress:   relayAddress,
+		TLSVersion:     result.TLSVersion,
+		TLSCipherSuite: result.TLSCipherSuite,
+		HTTPMethod:     result.HTTPMethod,
+		HTTPPath:       result.HTTPPath,
+		HTTPStatus:     result.HTTPStatus,
+		DNSQName:       result.DNSQName,
+		DNSQType:       result.DNSQType,
+	}); emitErr != nil {
+		a.log.WithError(emitErr).Error("emit network event")
+	}
+	return nil
+}
+
+func (a *networkActivity) Cleanup(ctx context.Context) error { return nil }
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rwool/red/events"
+	"github.com/sirupsen/logrus"
+)
+
+// Activity is a single, self-contained unit of EDR-triggering behavior. The
+// registry-based runner composes activities in whatever order a config file
+// specifies, instead of the fixed sequence that RunActivities used to
+// hard-code.
+type Activity interface {
+	// Name identifies the activity for logging and error messages.
+	Name() string
+	// Prepare performs setup that must happen before Run, such as validating
+	// parameters or allocating resources.
+	Prepare(ctx context.Context) error
+	// Run performs the activity's triggering behavior.
+	Run(ctx context.Context) error
+	// Cleanup releases anything allocated in Prepare or Run. It is called
+	// even if Prepare or Run returned an error.
+	Cleanup(ctx context.Context) error
+}
+
+// Factory constructs an Activity from the per-activity parameters found in a
+// config file, logging through log and emitting structured events through
+// emit.
+type Factory func(log *logrus.Entry, emit events.Emitter, params map[string]interface{}) (Activity, error)
+
+// registry holds the built-in activity factories keyed by the name used in
+// config files.
+var registry = map[string]Factory{}
+
+// RegisterActivity adds f to the registry under name. It panics on a
+// duplicate name, mirroring the semantics of sql.Register, since a collision
+// can only happen from a programming mistake.
+func RegisterActivity(name string, f Factory) {
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("red: activity %q already registered", name))
+	}
+	registry[name] = f
+}
+
+// NewActivity looks up name in the registry and constructs an Activity from
+// params.
+func NewActivity(log *logrus.Entry, emit events.Emitter, name string, params map[string]interface{}) (Activity, error) {
+	f, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown activity %q", name)
+	}
+	return f(log, emit, params)
+}
+
+// paramString extracts the string value of key from params, returning def if
+// key is absent. It returns an error if key is present but not a string.
+func paramString(params map[string]interface{}, key, def string) (string, error) {
+	raw, ok := params[key]
+	if !ok {
+		return def, nil
+	}
+	s, ok := raw.(string)
+	if !ok {
+		return "", fmt.Errorf("parameter %q must be a string", key)
+	}
+	return s, nil
+}
+
+// paramInt extracts the int value of key from params, returning def if key
+// is absent. It accepts both int (as yaml.v3 decodes integer scalars) and
+// float64 (as encoding/json decodes all numbers), and returns an error if
+// key is present but neither.
+func paramInt(params map[string]interface{}, key string, def int) (int, error) {
+	raw, ok := params[key]
+	if !ok {
+		return def, nil
+	}
+	switch v := raw.(type) {
+	case int:
+		return v, nil
+	case float64:
+		return int(v), nil
+	default:
+		return 0, fmt.Errorf("parameter %q must be a number", key)
+	}
+}
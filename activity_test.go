@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/rwool/red/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewActivityUnknown(t *testing.T) {
+	t.Parallel()
+	log := newLogger(t).WithField("test", t.Name())
+	_, err := NewActivity(log, events.NewLogrusEmitter(log), "does-not-exist", nil)
+	assert.Error(t, err)
+}
+
+func TestNewNetworkActivityUnknownTransport(t *testing.T) {
+	t.Parallel()
+	log := newLogger(t).WithField("test", t.Name())
+	_, err := newNetworkActivity(log, events.NewLogrusEmitter(log), map[string]interface{}{
+		"transport": "bogus",
+	})
+	assert.Error(t, err)
+}
+
+func TestNewNetworkActivityRoomRequiresTCP(t *testing.T) {
+	t.Parallel()
+	log := newLogger(t).WithField("test", t.Name())
+	_, err := newNetworkActivity(log, events.NewLogrusEmitter(log), map[string]interface{}{
+		"transport":   "udp",
+		"destination": "127.0.0.1:1234",
+		"room":        "room-1",
+	})
+	assert.Error(t, err)
+}
+
+func TestNewNetworkActivityRoomRequiresDestination(t *testing.T) {
+	t.Parallel()
+	log := newLogger(t).WithField("test", t.Name())
+	_, err := newNetworkActivity(log, events.NewLogrusEmitter(log), map[string]interface{}{
+		"room": "room-1",
+	})
+	assert.Error(t, err)
+}
+
+func TestNewNetworkActivityRoomWithDestinationAndTCP(t *testing.T) {
+	t.Parallel()
+	log := newLogger(t).WithField("test", t.Name())
+	_, err := newNetworkActivity(log, events.NewLogrusEmitter(log), map[string]interface{}{
+		"destination": "127.0.0.1:1234",
+		"room":        "room-1",
+	})
+	assert.NoError(t, err)
+}
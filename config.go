@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ActivityConfig is one entry in a Config's activity list.
+type ActivityConfig struct {
+	Name   string                 `yaml:"name" json:"name"`
+	Params map[string]interface{} `yaml:"params" json:"params"`
+	// Schedule, if set, makes RunSchedule repeat this activity on a cadence
+	// instead of running it once. It has no effect on RunActivities.
+	Schedule *Schedule `yaml:"schedule" json:"schedule"`
+}
+
+// Schedule describes how RunSchedule repeats an activity: every Interval
+// (plus up to Jitter of random slop), Count times (0 = until the context is
+// done), with at most Concurrency instances in flight at once.
+type Schedule struct {
+	Interval    Duration `yaml:"interval" json:"interval"`
+	Jitter      Duration `yaml:"jitter" json:"jitter"`
+	Count       int      `yaml:"count" json:"count"`
+	Concurrency int      `yaml:"concurrency" json:"concurrency"`
+}
+
+// Duration is a time.Duration that unmarshals from the same strings
+// time.ParseDuration accepts (e.g. "30s", "5m"), rather than a raw integer
+// number of nanoseconds.
+type Duration time.Duration
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	var s string
+	if err := value.Decode(&s); err != nil {
+		return fmt.Errorf("duration must be a string: %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Config describes which activities to run, in what order, and with what
+// per-activity parameters. JSON is a subset of YAML, so the same loader
+// handles both.
+type Config struct {
+	Activities []ActivityConfig `yaml:"activities" json:"activities"`
+}
+
+// LoadConfig reads and parses the config file at path.
+func LoadConfig(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config file: %w", err)
+	}
+	var c Config
+	if err := yaml.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("unable to parse config file: %w", err)
+	}
+	return &c, nil
+}
+
+// defaultConfig synthesizes a Config equivalent to the pre-registry
+// hard-coded activity sequence, so the legacy CLI flags keep working without
+// requiring a --config file.
+func defaultConfig(dir, fileExt, processArgs string) *Config {
+	return &Config{
+		Activities: []ActivityConfig{
+			{Name: "process", Params: map[string]interface{}{"arguments": processArgs}},
+			{Name: "file", Params: map[string]interface{}{"directory": dir, "extension": fileExt}},
+			{Name: "network", Params: map[string]interface{}{}},
+		},
+	}
+}
+
+// withRelay points cfg's "network" activity at a rendezvous relay instead of
+// the loopback listener Connect would otherwise create, for the --relay and
+// --room legacy flags. It is a no-op if relayAddr is empty.
+func withRelay(cfg *Config, relayAddr, room string) *Config {
+	if relayAddr == "" {
+		return cfg
+	}
+	for i, ac := range cfg.Activities {
+		if ac.Name != "network" {
+			continue
+		}
+		if ac.Params == nil {
+			ac.Params = map[string]interface{}{}
+		}
+		ac.Params["destination"] = relayAddr
+		ac.Params["room"] = room
+		cfg.Activities[i] = ac
+	}
+	return cfg
+}
@@ -0,0 +1,81 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Emitter publishes a typed event to a backend.
+type Emitter interface {
+	Emit(event interface{}) error
+}
+
+// LogrusEmitter emits events as fields on a logrus entry, so they appear
+// inline with the rest of a run's structured logging.
+type LogrusEmitter struct {
+	Log *logrus.Entry
+}
+
+// NewLogrusEmitter returns an Emitter that logs through log.
+func NewLogrusEmitter(log *logrus.Entry) *LogrusEmitter {
+	return &LogrusEmitter{Log: log}
+}
+
+// Emit logs event at info level with its ECS fields flattened onto the
+// entry.
+func (e *LogrusEmitter) Emit(event interface{}) error {
+	fields, err := toFields(event)
+	if err != nil {
+		return err
+	}
+	e.Log.WithFields(fields).Info("event")
+	return nil
+}
+
+// NDJSONEmitter emits events as newline-delimited JSON, one event per line,
+// for harnesses that want to consume events directly without parsing log
+// lines. It is safe for concurrent use by multiple goroutines, since a
+// scheduled activity run may share one Emitter across its workers.
+type NDJSONEmitter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewNDJSONEmitter returns an Emitter that writes NDJSON records to w.
+func NewNDJSONEmitter(w io.Writer) *NDJSONEmitter {
+	return &NDJSONEmitter{w: w}
+}
+
+// Emit writes event to the underlying writer as a single JSON line.
+func (e *NDJSONEmitter) Emit(event interface{}) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("unable to marshal event: %w", err)
+	}
+	b = append(b, '\n')
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, err := e.w.Write(b); err != nil {
+		return fmt.Errorf("unable to write event: %w", err)
+	}
+	return nil
+}
+
+// toFields round-trips event through JSON so its ECS-tagged struct fields
+// become flat logrus.Fields keyed by their dotted ECS names.
+func toFields(event interface{}) (logrus.Fields, error) {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal event: %w", err)
+	}
+	var fields logrus.Fields
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, fmt.Errorf("unable to decode event fields: %w", err)
+	}
+	return fields, nil
+}
@@ -0,0 +1,49 @@
+package events
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNDJSONEmitter(t *testing.T) {
+	t.Parallel()
+	var buf strings.Builder
+	e := NewNDJSONEmitter(&buf)
+	ev := ProcessEvent{
+		Event:       NewEvent("T1059"),
+		PID:         1234,
+		Name:        "echo",
+		CommandLine: "echo hi",
+		User:        "root",
+	}
+	assert.NoError(t, e.Emit(ev))
+	assert.Contains(t, buf.String(), `"threat.technique.id":"T1059"`)
+	assert.Contains(t, buf.String(), `"process.pid":1234`)
+	assert.True(t, strings.HasSuffix(buf.String(), "\n"))
+}
+
+// TestNDJSONEmitterConcurrentEmit is a regression test for Emit racing on
+// the underlying writer when multiple goroutines share one Emitter, as the
+// scheduler's concurrent activity runs do.
+func TestNDJSONEmitterConcurrentEmit(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	e := NewNDJSONEmitter(&buf)
+	ev := ProcessEvent{Event: NewEvent("T1059"), PID: 1, Name: "echo"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, e.Emit(ev))
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 50, strings.Count(buf.String(), "\n"))
+}
@@ -0,0 +1,83 @@
+// Package events defines the structured event types emitted by the
+// activities in the parent package and the backends that publish them.
+//
+// Field names follow the Elastic Common Schema (ECS) so downstream EDR test
+// harnesses can match on `process.pid`, `file.path`, and similar
+// conventional names instead of regex-parsing free-form log messages.
+package events
+
+import "time"
+
+// Event is the envelope every typed event embeds. It carries the fields ECS
+// and MITRE ATT&CK expect on every record, regardless of which activity
+// produced it.
+type Event struct {
+	Timestamp time.Time `json:"@timestamp"`
+	// Technique is the MITRE ATT&CK (sub-)technique ID the activity is
+	// simulating, e.g. "T1059" for process execution.
+	Technique string `json:"threat.technique.id,omitempty"`
+}
+
+// NewEvent returns an Event stamped with the current time and technique.
+func NewEvent(technique string) Event {
+	return Event{
+		Timestamp: time.Now(),
+		Technique: technique,
+	}
+}
+
+// ProcessEvent describes the creation of a process.
+type ProcessEvent struct {
+	Event
+	PID         int    `json:"process.pid"`
+	Name        string `json:"process.name"`
+	CommandLine string `json:"process.command_line"`
+	User        string `json:"user.name"`
+}
+
+// FileEvent describes a single file system operation.
+type FileEvent struct {
+	Event
+	Path   string `json:"file.path"`
+	Action string `json:"file.action"`
+	User   string `json:"user.name"`
+}
+
+// NetworkEvent describes a network connection and the data transferred over
+// it.
+type NetworkEvent struct {
+	Event
+	SourceIP      string `json:"source.ip"`
+	DestinationIP string `json:"destination.ip"`
+	Transport     string `json:"network.transport"`
+	BytesSent     int64  `json:"network.bytes"`
+	User          string `json:"user.name"`
+	// RelayAddress is set when the connection was routed through a
+	// rendezvous relay instead of dialing DestinationIP directly; it is the
+	// relay's own address.
+	RelayAddress string `json:"network.relay_address,omitempty"`
+
+	// TLSVersion and TLSCipherSuite are set for the TLS transport's loopback
+	// listener, from the negotiated connection state. They are left empty
+	// for HTTPS and for TLS dialed directly to a remote destination.
+	TLSVersion     string `json:"tls.version,omitempty"`
+	TLSCipherSuite string `json:"tls.cipher,omitempty"`
+
+	// HTTPMethod, HTTPPath, and HTTPStatus are set for the HTTP and HTTPS
+	// transports.
+	HTTPMethod string `json:"http.request.method,omitempty"`
+	HTTPPath   string `json:"url.path,omitempty"`
+	HTTPStatus int    `json:"http.response.status_code,omitempty"`
+
+	// DNSQName and DNSQType are set for the DNS transport.
+	DNSQName string `json:"dns.question.name,omitempty"`
+	DNSQType string `json:"dns.question.type,omitempty"`
+}
+
+// AuthEvent describes an authentication-relevant outcome, such as the user
+// context an activity ran under.
+type AuthEvent struct {
+	Event
+	User    string `json:"user.name"`
+	Outcome string `json:"event.outcome"`
+}
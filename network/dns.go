@@ -0,0 +1,107 @@
+package network
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// connectDNS sends a single A query for spec.Destination (used as a hostname
+// here, not host:port) to the default system resolver and reports the qname
+// and qtype in the Result. It is the same for Connect and RemoteConnect since a DNS query
+// has no loopback-listener variant.
+func connectDNS(ctx context.Context, log *logrus.Entry, spec Spec) (Result, error) {
+	qname := spec.Destination
+	if qname == "" {
+		qname = "localhost"
+	}
+
+	conf, err := readResolvConf()
+	if err != nil {
+		return Result{}, fmt.Errorf("unable to determine DNS server: %w", err)
+	}
+
+	query, id := buildAQuery(qname)
+	conn, err := net.Dial("udp", net.JoinHostPort(conf, "53"))
+	if err != nil {
+		return Result{}, fmt.Errorf("unable to dial DNS server: %w", err)
+	}
+	defer conn.Close()
+
+	if dl, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(dl); err != nil {
+			return Result{}, fmt.Errorf("unable to set DNS connection deadline: %w", err)
+		}
+	}
+
+	sent, err := conn.Write(query)
+	if err != nil {
+		return Result{}, fmt.Errorf("unable to send DNS query: %w", err)
+	}
+
+	buf := make([]byte, 512)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return Result{BytesSent: int64(sent), DNSQName: qname, DNSQType: "A"}, fmt.Errorf("unable to read DNS response: %w", err)
+	}
+	if n < 2 || binary.BigEndian.Uint16(buf[:2]) != id {
+		return Result{BytesSent: int64(sent), DNSQName: qname, DNSQType: "A"}, fmt.Errorf("unexpected DNS response")
+	}
+
+	return Result{BytesSent: int64(sent), DNSQName: qname, DNSQType: "A"}, nil
+}
+
+// buildAQuery constructs a minimal, single-question DNS A query for name and
+// returns it along with the transaction ID used so the caller can match it
+// against the response header.
+func buildAQuery(name string) (query []byte, id uint16) {
+	id = uint16(len(name)*7919 + 1) // Deterministic, avoids a math/rand dependency here.
+
+	var header [12]byte
+	binary.BigEndian.PutUint16(header[0:2], id)
+	header[2] = 0x01                           // Recursion desired.
+	binary.BigEndian.PutUint16(header[4:6], 1) // QDCOUNT.
+
+	var question []byte
+	for _, label := range strings.Split(strings.Trim(name, "."), ".") {
+		question = append(question, byte(len(label)))
+		question = append(question, label...)
+	}
+	question = append(question, 0x00)       // Root label.
+	question = append(question, 0x00, 0x01) // QTYPE A.
+	question = append(question, 0x00, 0x01) // QCLASS IN.
+
+	return append(header[:], question...), id
+}
+
+// readResolvConf returns the first nameserver listed in /etc/resolv.conf,
+// falling back to the loopback resolver if the file is missing or lists
+// none.
+func readResolvConf() (string, error) {
+	f, err := os.Open("/etc/resolv.conf")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "127.0.0.1", nil
+		}
+		return "", fmt.Errorf("unable to open /etc/resolv.conf: %w", err)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 2 && fields[0] == "nameserver" {
+			return fields[1], nil
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return "", fmt.Errorf("unable to read /etc/resolv.conf: %w", err)
+	}
+	return "127.0.0.1", nil
+}
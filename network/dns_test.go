@@ -0,0 +1,37 @@
+package network
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// connectDNS itself dials the system resolver from /etc/resolv.conf, which
+// isn't reachable in a hermetic test environment, so this only covers the
+// deterministic query-building helper.
+func TestBuildAQuery(t *testing.T) {
+	t.Parallel()
+
+	query, id := buildAQuery("example.com")
+	assert.Equal(t, id, binary.BigEndian.Uint16(query[:2]))
+	assert.Equal(t, byte(0x01), query[2], "recursion desired flag")
+	assert.Equal(t, uint16(1), binary.BigEndian.Uint16(query[4:6]), "QDCOUNT")
+
+	question := query[12:]
+	assert.Equal(t, byte(7), question[0], "label length for \"example\"")
+	assert.Equal(t, "example", string(question[1:8]))
+	assert.Equal(t, byte(3), question[8], "label length for \"com\"")
+	assert.Equal(t, "com", string(question[9:12]))
+	assert.Equal(t, byte(0x00), question[12], "root label")
+	assert.Equal(t, uint16(1), binary.BigEndian.Uint16(question[13:15]), "QTYPE A")
+	assert.Equal(t, uint16(1), binary.BigEndian.Uint16(question[15:17]), "QCLASS IN")
+}
+
+func TestBuildAQueryIDDeterministic(t *testing.T) {
+	t.Parallel()
+
+	_, id1 := buildAQuery("same-name.test")
+	_, id2 := buildAQuery("same-name.test")
+	assert.Equal(t, id1, id2)
+}
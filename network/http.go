@@ -0,0 +1,106 @@
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+)
+
+// connectLoopbackHTTP spins up an http.Server on an ephemeral loopback port,
+// POSTs spec.Payload to it, and reports the request method/path and the
+// response status in the Result. HTTPS uses the same self-signed certificate as the TLS
+// transport.
+func connectLoopbackHTTP(ctx context.Context, log *logrus.Entry, spec Spec) (Result, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return Result{}, fmt.Errorf("unable to create HTTP listener: %w", err)
+	}
+
+	seen := make(chan Result, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		n, _ := io.Copy(ioutil.Discard, r.Body)
+		seen <- Result{HTTPMethod: r.Method, HTTPPath: r.URL.Path, BytesSent: n}
+		w.WriteHeader(http.StatusOK)
+	})
+	srv := &http.Server{Handler: mux}
+	if spec.Transport == HTTPS {
+		cert, err := selfSignedCert()
+		if err != nil {
+			return Result{}, fmt.Errorf("unable to generate self-signed certificate: %w", err)
+		}
+		srv.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+		l = tls.NewListener(l, srv.TLSConfig)
+	}
+
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.Serve(l) }()
+	defer func() {
+		if err := srv.Close(); err != nil {
+			log.WithError(err).Error("close HTTP server")
+		}
+	}()
+
+	spec.Destination = l.Addr().String()
+	status, _, err := sendHTTPRequest(ctx, log, spec)
+	if err != nil {
+		return Result{}, err
+	}
+
+	result := <-seen
+	result.HTTPStatus = status
+	return result, nil
+}
+
+// dialHTTP sends an HTTP request to spec.Destination directly, without
+// starting a server.
+func dialHTTP(ctx context.Context, log *logrus.Entry, spec Spec) (Result, error) {
+	status, sent, err := sendHTTPRequest(ctx, log, spec)
+	return Result{HTTPStatus: status, HTTPMethod: http.MethodPost, HTTPPath: "/", BytesSent: sent}, err
+}
+
+// sendHTTPRequest POSTs spec's (throttled) payload to spec.Destination and
+// returns the response status and the number of payload bytes the request
+// body actually read, counted as http.Client streams it rather than assumed
+// from spec up front.
+func sendHTTPRequest(ctx context.Context, log *logrus.Entry, spec Spec) (int, int64, error) {
+	scheme := "http"
+	if spec.Transport == HTTPS {
+		scheme = "https"
+	}
+	body := &countingReader{r: throttledPayload(log, spec)}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, scheme+"://"+spec.Destination+"/", body)
+	if err != nil {
+		return 0, 0, fmt.Errorf("unable to build request: %w", err)
+	}
+
+	client := &http.Client{}
+	if scheme == "https" {
+		client.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, body.n, fmt.Errorf("unable to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode, body.n, nil
+}
+
+// countingReader wraps an io.Reader, tallying how many bytes have been read
+// through it so far.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,80 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+// connectICMP sends a single ICMP echo request to spec.Destination (used as
+// a host, not host:port) and waits for the matching reply. It is the same
+// for Connect and RemoteConnect since ICMP has no loopback-listener variant;
+// an empty Destination defaults to the loopback address.
+//
+// Sending raw ICMP packets generally requires CAP_NET_RAW or root, since this
+// opens an "ip4:icmp" raw socket rather than the unprivileged "udp4" ICMP
+// datagram socket available on Linux.
+func connectICMP(ctx context.Context, log *logrus.Entry, spec Spec) (Result, error) {
+	dest := spec.Destination
+	if dest == "" {
+		dest = "127.0.0.1"
+	}
+
+	conn, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0")
+	if err != nil {
+		return Result{}, fmt.Errorf("unable to open ICMP socket (requires CAP_NET_RAW or root): %w", err)
+	}
+	defer conn.Close()
+	if dl, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(dl); err != nil {
+			return Result{}, fmt.Errorf("unable to set ICMP socket deadline: %w", err)
+		}
+	}
+
+	addr, err := net.ResolveIPAddr("ip4", dest)
+	if err != nil {
+		return Result{}, fmt.Errorf("unable to resolve %q: %w", dest, err)
+	}
+
+	id := os.Getpid() & 0xffff
+	msg := icmp.Message{
+		Type: ipv4.ICMPTypeEcho,
+		Code: 0,
+		Body: &icmp.Echo{
+			ID:   id,
+			Seq:  1,
+			Data: []byte("red icmp echo"),
+		},
+	}
+	b, err := msg.Marshal(nil)
+	if err != nil {
+		return Result{}, fmt.Errorf("unable to marshal ICMP echo request: %w", err)
+	}
+
+	sent, err := conn.WriteTo(b, addr)
+	if err != nil {
+		return Result{}, fmt.Errorf("unable to send ICMP echo request: %w", err)
+	}
+
+	// The raw socket also sees other ICMP traffic, including the echo request
+	// itself when dest is loopback, so skip anything that isn't our reply.
+	reply := make([]byte, 1500)
+	for {
+		n, _, err := conn.ReadFrom(reply)
+		if err != nil {
+			return Result{BytesSent: int64(sent)}, fmt.Errorf("unable to read ICMP echo reply: %w", err)
+		}
+		parsed, err := icmp.ParseMessage(1, reply[:n])
+		if err != nil {
+			return Result{BytesSent: int64(sent)}, fmt.Errorf("unable to parse ICMP echo reply: %w", err)
+		}
+		if echo, ok := parsed.Body.(*icmp.Echo); ok && parsed.Type == ipv4.ICMPTypeEchoReply && echo.ID == id {
+			return Result{BytesSent: int64(sent)}, nil
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// Package network generalizes the original loopback-TCP trigger into a
+// dialer covering the transports and application protocols EDR sensors
+// distinguish: TCP, UDP, TLS, HTTP(S), DNS, and ICMP.
+package network
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/rwool/red/ratelimit"
+	"github.com/sirupsen/logrus"
+)
+
+// throttledPayload wraps spec.Payload (or an empty reader, if unset) with a
+// ratelimit.Reader when spec.RateBytesPerSec is set, otherwise it is
+// returned unchanged.
+func throttledPayload(log *logrus.Entry, spec Spec) io.Reader {
+	r := spec.Payload
+	if r == nil {
+		r = noopReader{}
+	}
+	if spec.RateBytesPerSec <= 0 {
+		return r
+	}
+	return ratelimit.NewReader(r, spec.RateBytesPerSec, spec.ChunkSize, log)
+}
+
+// throttledReceiver wraps spec.Received with a ratelimit.Writer when
+// spec.RateBytesPerSec is set, otherwise it is returned unchanged. It is the
+// receiving-side counterpart to throttledPayload, for loopback listeners
+// that accept a throttled transfer.
+func throttledReceiver(log *logrus.Entry, spec Spec) io.Writer {
+	w := spec.Received
+	if w == nil {
+		w = ioutil.Discard
+	}
+	if spec.RateBytesPerSec <= 0 {
+		return w
+	}
+	return ratelimit.NewWriter(w, spec.RateBytesPerSec, spec.ChunkSize, log)
+}
+
+// Transport selects which protocol Connect or RemoteConnect uses.
+type Transport string
+
+const (
+	TCP   Transport = "tcp"
+	UDP   Transport = "udp"
+	TLS   Transport = "tls"
+	HTTP  Transport = "http"
+	HTTPS Transport = "https"
+	DNS   Transport = "dns"
+	ICMP  Transport = "icmp"
+)
+
+// Spec describes one network activity.
+type Spec struct {
+	Transport Transport
+	// Destination is a host:port (host only for ICMP and DNS) to dial.
+	// RemoteConnect requires it to be set; Connect treats an empty
+	// Destination as "create an ephemeral loopback listener and dial that".
+	Destination string
+	// Payload is the data to send. It is ignored by DNS and ICMP, which send
+	// a fixed query/echo packet.
+	Payload io.Reader
+	// Received, if set, is where data accepted by a loopback listener (TCP,
+	// UDP, TLS) or forwarded by a relay (see Room) is written. The HTTP(S)
+	// listener discards the request body, and RemoteConnect ignores Received
+	// unless Room is set.
+	Received io.Writer
+
+	// RateBytesPerSec, if greater than zero, throttles the Payload send to
+	// that rate via the ratelimit package, for simulating slow exfiltration
+	// or beaconing instead of an unbounded transfer. The send is only
+	// throttled for the streamed transports (TCP, TLS, HTTP, HTTPS); UDP
+	// sends a single datagram and DNS/ICMP send a fixed query/echo, so none
+	// of those have a stream to throttle. Writes to Received are throttled to
+	// the same rate.
+	RateBytesPerSec int
+	// ChunkSize caps how many bytes are sent per read when RateBytesPerSec
+	// is set; it is ignored otherwise. Defaults to 4096 if <= 0.
+	ChunkSize int
+
+	// Room, if set, routes the connection through a rendezvous relay (see
+	// the `red relay` subcommand) instead of dialing Destination directly:
+	// Destination is the relay's address, and Room is the shared code the
+	// relay uses to pair this connection with its peer. Only the TCP
+	// transport supports it.
+	Room string
+}
+
+// Result reports what a Connect or RemoteConnect call actually did. Only the
+// fields relevant to the Spec's Transport are populated.
+type Result struct {
+	BytesSent int64
+
+	TLSVersion     string
+	TLSCipherSuite string
+
+	HTTPMethod string
+	HTTPPath   string
+	HTTPStatus int
+
+	DNSQName string
+	DNSQType string
+}
+
+// Connect runs the activity described by spec. If spec.Destination is empty,
+// an ephemeral loopback listener is created first and spec.Destination is
+// set to its address; this is the pattern the original LocalhostTCPConnect
+// used and is retained so tests don't need an external endpoint.
+func Connect(ctx context.Context, log *logrus.Entry, spec Spec) (Result, error) {
+	if spec.Destination != "" {
+		return RemoteConnect(ctx, log, spec)
+	}
+	switch spec.Transport {
+	case TCP:
+		return connectLoopbackStream(ctx, log, spec, false)
+	case TLS:
+		return connectLoopbackStream(ctx, log, spec, true)
+	case UDP:
+		return connectLoopbackPacket(ctx, log, spec)
+	case HTTP, HTTPS:
+		return connectLoopbackHTTP(ctx, log, spec)
+	case DNS:
+		return connectDNS(ctx, log, spec)
+	case ICMP:
+		return connectICMP(ctx, log, spec)
+	default:
+		return Result{}, fmt.Errorf("unknown transport %q", spec.Transport)
+	}
+}
+
+// RemoteConnect dials spec.Destination directly; it never creates a
+// listener. It is the variant to use against a user-supplied remote host
+// instead of the loopback ephemeral listener Connect sets up.
+func RemoteConnect(ctx context.Context, log *logrus.Entry, spec Spec) (Result, error) {
+	if spec.Destination == "" {
+		return Result{}, fmt.Errorf("destination is required for RemoteConnect")
+	}
+	if spec.Room != "" {
+		if spec.Transport != TCP {
+			return Result{}, fmt.Errorf("room is only supported for the %q transport, got %q", TCP, spec.Transport)
+		}
+		return RelayConnect(ctx, log, spec)
+	}
+	switch spec.Transport {
+	case TCP:
+		return dialStream(ctx, log, spec, false)
+	case TLS:
+		return dialStream(ctx, log, spec, true)
+	case UDP:
+		return dialPacket(ctx, log, spec)
+	case HTTP, HTTPS:
+		return dialHTTP(ctx, log, spec)
+	case DNS:
+		return connectDNS(ctx, log, spec)
+	case ICMP:
+		return connectICMP(ctx, log, spec)
+	default:
+		return Result{}, fmt.Errorf("unknown transport %q", spec.Transport)
+	}
+}
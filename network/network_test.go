@@ -0,0 +1,167 @@
+package network
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnectTCP(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var buf strings.Builder
+	result, err := Connect(ctx, logrus.NewEntry(logrus.New()), Spec{
+		Transport: TCP,
+		Payload:   strings.NewReader("hello"),
+		Received:  &buf,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len("hello")), result.BytesSent)
+	assert.Equal(t, "hello", buf.String())
+}
+
+func TestConnectTCPThrottlesReceive(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	payload := strings.Repeat("x", 100)
+	var buf strings.Builder
+	start := time.Now()
+	result, err := Connect(ctx, logrus.NewEntry(logrus.New()), Spec{
+		Transport:       TCP,
+		Payload:         strings.NewReader(payload),
+		Received:        &buf,
+		RateBytesPerSec: 50,
+		ChunkSize:       50,
+	})
+	elapsed := time.Since(start)
+
+	assert.NoError(t, err)
+	assert.Equal(t, payload, buf.String())
+	assert.Equal(t, int64(len(payload)), result.BytesSent)
+	// 100 bytes at 50 B/s in 50-byte chunks. Both the sending side's
+	// ratelimit.Reader and the receiving side's ratelimit.Writer are
+	// throttled; the sender's bucket starts with exactly one chunk's worth
+	// of tokens, so the second chunk and the final EOF read each wait about
+	// a second, and the receiver's Writer, paced by the sender, does not
+	// add to that.
+	assert.GreaterOrEqual(t, elapsed, 800*time.Millisecond)
+}
+
+func TestConnectUnknownTransport(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := Connect(ctx, logrus.NewEntry(logrus.New()), Spec{Transport: "bogus"})
+	assert.Error(t, err)
+}
+
+func TestConnectTLS(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var buf strings.Builder
+	result, err := Connect(ctx, logrus.NewEntry(logrus.New()), Spec{
+		Transport: TLS,
+		Payload:   strings.NewReader("hello"),
+		Received:  &buf,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len("hello")), result.BytesSent)
+	assert.Equal(t, "hello", buf.String())
+	assert.NotEmpty(t, result.TLSVersion)
+	assert.NotEmpty(t, result.TLSCipherSuite)
+}
+
+func TestConnectUDP(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var buf strings.Builder
+	result, err := Connect(ctx, logrus.NewEntry(logrus.New()), Spec{
+		Transport: UDP,
+		Payload:   strings.NewReader("hello"),
+		Received:  &buf,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len("hello")), result.BytesSent)
+	assert.Equal(t, "hello", buf.String())
+}
+
+func TestConnectHTTP(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := Connect(ctx, logrus.NewEntry(logrus.New()), Spec{
+		Transport: HTTP,
+		Payload:   strings.NewReader("hello"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len("hello")), result.BytesSent)
+	assert.Equal(t, http.MethodPost, result.HTTPMethod)
+	assert.Equal(t, "/", result.HTTPPath)
+	assert.Equal(t, http.StatusOK, result.HTTPStatus)
+}
+
+func TestConnectHTTPS(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := Connect(ctx, logrus.NewEntry(logrus.New()), Spec{
+		Transport: HTTPS,
+		Payload:   strings.NewReader("hello"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len("hello")), result.BytesSent)
+	assert.Equal(t, http.StatusOK, result.HTTPStatus)
+}
+
+// TestRemoteConnectHTTPBytesSent is a regression test for dialHTTP reporting
+// BytesSent as 0 regardless of payload size: RemoteConnect's HTTP path must
+// count the bytes the request body actually streamed, not just the status.
+func TestRemoteConnectHTTPBytesSent(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	payload := "twenty-byte-payload!"
+	result, err := RemoteConnect(ctx, logrus.NewEntry(logrus.New()), Spec{
+		Transport:   HTTP,
+		Destination: strings.TrimPrefix(srv.URL, "http://"),
+		Payload:     strings.NewReader(payload),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(payload)), result.BytesSent)
+}
+
+func TestConnectICMP(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := Connect(ctx, logrus.NewEntry(logrus.New()), Spec{Transport: ICMP})
+	if err != nil {
+		t.Skipf("ICMP requires CAP_NET_RAW or root, skipping: %v", err)
+	}
+	assert.Greater(t, result.BytesSent, int64(0))
+}
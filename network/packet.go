@@ -0,0 +1,90 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+// connectLoopbackPacket creates an ephemeral UDP loopback listener, sends
+// spec.Payload to it in a single datagram, and writes what the listener
+// receives to spec.Received.
+func connectLoopbackPacket(ctx context.Context, log *logrus.Entry, spec Spec) (Result, error) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		return Result{}, fmt.Errorf("unable to create UDP listener: %w", err)
+	}
+	defer func() {
+		if err := pc.Close(); err != nil {
+			log.WithError(err).Error("close UDP listener")
+		}
+	}()
+	if dl, ok := ctx.Deadline(); ok {
+		if err := pc.SetDeadline(dl); err != nil {
+			return Result{}, fmt.Errorf("unable to set listener deadline: %w", err)
+		}
+	}
+
+	// Built before the receive goroutine starts and before spec.Destination
+	// is set below, so the goroutine never reads spec concurrently with the
+	// send-side mutation of it.
+	recv := throttledReceiver(log, spec)
+
+	g, _ := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		buf := make([]byte, 64*1024)
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			return fmt.Errorf("error reading datagram: %w", err)
+		}
+		if _, err := recv.Write(buf[:n]); err != nil {
+			return fmt.Errorf("error writing received datagram: %w", err)
+		}
+		return nil
+	})
+
+	spec.Destination = pc.LocalAddr().String()
+	sent, err := sendDatagram(spec)
+	if err != nil {
+		return Result{BytesSent: sent}, err
+	}
+
+	if err := g.Wait(); err != nil {
+		return Result{BytesSent: sent}, fmt.Errorf("listener error: %w", err)
+	}
+	return Result{BytesSent: sent}, nil
+}
+
+// dialPacket sends spec.Payload to spec.Destination as a single datagram,
+// without creating a listener.
+func dialPacket(ctx context.Context, log *logrus.Entry, spec Spec) (Result, error) {
+	sent, err := sendDatagram(spec)
+	return Result{BytesSent: sent}, err
+}
+
+func sendDatagram(spec Spec) (int64, error) {
+	conn, err := net.Dial("udp", spec.Destination)
+	if err != nil {
+		return 0, fmt.Errorf("unable to dial %q: %w", spec.Destination, err)
+	}
+	defer conn.Close()
+
+	buf := make([]byte, 64*1024)
+	n := 0
+	if spec.Payload != nil {
+		n, err = spec.Payload.Read(buf)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return 0, fmt.Errorf("error reading payload: %w", err)
+		}
+	}
+	sent, err := conn.Write(buf[:n])
+	if err != nil {
+		return int64(sent), fmt.Errorf("error writing datagram: %w", err)
+	}
+	return int64(sent), nil
+}
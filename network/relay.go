@@ -0,0 +1,78 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+// RelayConnect dials spec.Destination as a rendezvous relay (the `red relay`
+// subcommand) instead of a loopback listener: it sends spec.Room as a
+// newline-terminated handshake, then concurrently sends spec.Payload to the
+// connection and copies anything the relay forwards back into
+// spec.Received. Two activities pointed at the same relay with the same
+// Room are paired up by the relay and exchange data as if Connect had
+// created the listener locally, except each side runs on its own host and
+// produces its own, independently observable network telemetry.
+//
+// This is the same deadline/errgroup pattern connectLoopbackStream uses, with
+// the accept-then-dial pair replaced by a single dial to the relay.
+func RelayConnect(ctx context.Context, log *logrus.Entry, spec Spec) (Result, error) {
+	if spec.Destination == "" {
+		return Result{}, fmt.Errorf("destination (relay address) is required for RelayConnect")
+	}
+	if spec.Room == "" {
+		return Result{}, fmt.Errorf("room is required for RelayConnect")
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", spec.Destination)
+	if err != nil {
+		return Result{}, fmt.Errorf("unable to dial relay %q: %w", spec.Destination, err)
+	}
+	if dl, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(dl); err != nil {
+			return Result{}, fmt.Errorf("unable to set connection deadline: %w", err)
+		}
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			log.WithError(err).Error("close relay connection")
+		}
+	}()
+
+	if _, err := fmt.Fprintf(conn, "%s\n", spec.Room); err != nil {
+		return Result{}, fmt.Errorf("unable to send room code to relay: %w", err)
+	}
+
+	var result Result
+	g, _ := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		w := throttledReceiver(log, spec)
+		if _, err := io.Copy(w, conn); err != nil {
+			return fmt.Errorf("error copying data from relay: %w", err)
+		}
+		return nil
+	})
+
+	buf := make([]byte, bufferSize(spec))
+	sent, err := io.CopyBuffer(conn, throttledPayload(log, spec), buf)
+	result.BytesSent = sent
+	if err != nil {
+		return result, fmt.Errorf("error copying data to relay: %w", err)
+	}
+	if cw, ok := conn.(interface{ CloseWrite() error }); ok {
+		if err := cw.CloseWrite(); err != nil {
+			return result, fmt.Errorf("error closing write side of relay connection: %w", err)
+		}
+	}
+
+	if err := g.Wait(); err != nil {
+		return result, err
+	}
+	return result, nil
+}
@@ -0,0 +1,129 @@
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+// connectLoopbackStream creates an ephemeral TCP (or TLS, if useTLS) loopback
+// listener, dials it, and sends spec.Payload to it, writing anything the
+// listener accepts to spec.Received. This is the original
+// LocalhostTCPConnect pattern, generalized to also cover TLS.
+func connectLoopbackStream(ctx context.Context, log *logrus.Entry, spec Spec, useTLS bool) (Result, error) {
+	var l net.Listener
+	tcpL, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 0})
+	if err != nil {
+		return Result{}, fmt.Errorf("unable to create TCP listener: %w", err)
+	}
+	l = tcpL
+	if useTLS {
+		cert, err := selfSignedCert()
+		if err != nil {
+			return Result{}, fmt.Errorf("unable to generate self-signed certificate: %w", err)
+		}
+		l = tls.NewListener(tcpL, &tls.Config{Certificates: []tls.Certificate{cert}})
+	}
+	if dl, ok := ctx.Deadline(); ok {
+		if err := tcpL.SetDeadline(dl); err != nil {
+			return Result{}, fmt.Errorf("unable to set listener deadline: %w", err)
+		}
+	}
+	defer func() {
+		if err := l.Close(); err != nil {
+			log.WithError(err).Error("close listener")
+		}
+	}()
+
+	// Built before the accept goroutine starts and before spec.Destination is
+	// set below, so the goroutine never reads spec concurrently with the
+	// dial-side mutation of it.
+	recv := throttledReceiver(log, spec)
+
+	var result Result
+	g, _ := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		conn, err := l.Accept()
+		if err != nil {
+			return fmt.Errorf("error accepting connection: %w", err)
+		}
+		if useTLS {
+			if tlsConn, ok := conn.(*tls.Conn); ok {
+				if err := tlsConn.HandshakeContext(ctx); err != nil {
+					return fmt.Errorf("server TLS handshake error: %w", err)
+				}
+				state := tlsConn.ConnectionState()
+				result.TLSVersion = tls.VersionName(state.Version)
+				result.TLSCipherSuite = tls.CipherSuiteName(state.CipherSuite)
+			}
+		}
+		if _, err := io.Copy(recv, conn); err != nil {
+			return fmt.Errorf("error copying data from server connection: %w", err)
+		}
+		return nil
+	})
+
+	spec.Destination = tcpL.Addr().String()
+	sent, err := dialAndSend(ctx, log, spec, useTLS)
+	result.BytesSent = sent
+	if err != nil {
+		return result, err
+	}
+
+	if err := g.Wait(); err != nil {
+		return result, fmt.Errorf("listener error: %w", err)
+	}
+	return result, nil
+}
+
+// dialStream dials spec.Destination directly, without creating a listener.
+func dialStream(ctx context.Context, log *logrus.Entry, spec Spec, useTLS bool) (Result, error) {
+	sent, err := dialAndSend(ctx, log, spec, useTLS)
+	return Result{BytesSent: sent}, err
+}
+
+func dialAndSend(ctx context.Context, log *logrus.Entry, spec Spec, useTLS bool) (int64, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", spec.Destination)
+	if err != nil {
+		return 0, fmt.Errorf("unable to dial %q: %w", spec.Destination, err)
+	}
+	if useTLS {
+		tlsConn := tls.Client(conn, &tls.Config{InsecureSkipVerify: true})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return 0, fmt.Errorf("client TLS handshake error: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	buf := make([]byte, bufferSize(spec))
+	sent, err := io.CopyBuffer(conn, throttledPayload(log, spec), buf)
+	if err != nil {
+		return sent, fmt.Errorf("error copying data to connection: %w", err)
+	}
+	if err := conn.Close(); err != nil {
+		return sent, fmt.Errorf("error closing connection: %w", err)
+	}
+	return sent, nil
+}
+
+// bufferSize returns the io.CopyBuffer buffer size to use for spec: its
+// ChunkSize when rate limiting is enabled (so chunk boundaries are
+// observable), or a generic default otherwise.
+func bufferSize(spec Spec) int {
+	if spec.RateBytesPerSec > 0 && spec.ChunkSize > 0 {
+		return spec.ChunkSize
+	}
+	return 32 * 1024
+}
+
+// noopReader is an io.Reader that always returns io.EOF, used when a Spec is
+// given without a Payload.
+type noopReader struct{}
+
+func (noopReader) Read(p []byte) (int, error) { return 0, io.EOF }
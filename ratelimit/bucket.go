@@ -0,0 +1,48 @@
+// Package ratelimit provides throttling io.Reader/io.Writer wrappers used to
+// simulate slow exfiltration and beaconing traffic patterns, which a plain
+// io.Copy cannot produce.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// bucket is a token bucket with a one-second capacity: it holds up to
+// ratePerSec tokens and refills at ratePerSec tokens/second, so take blocks
+// just long enough to hold the long-run transfer rate to ratePerSec.
+type bucket struct {
+	mu         sync.Mutex
+	ratePerSec float64
+	tokens     float64
+	last       time.Time
+}
+
+func newBucket(ratePerSec int) *bucket {
+	return &bucket{
+		ratePerSec: float64(ratePerSec),
+		tokens:     float64(ratePerSec),
+		last:       time.Now(),
+	}
+}
+
+// take spends n tokens, then blocks until the bucket has refilled enough to
+// cover them. A shortfall leaves the balance negative, so the tokens slept
+// for here are not handed out again to the next caller.
+func (b *bucket) take(n int) {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.ratePerSec
+	if b.tokens > b.ratePerSec {
+		b.tokens = b.ratePerSec
+	}
+	b.last = now
+
+	b.tokens -= float64(n)
+	deficit := -b.tokens
+	b.mu.Unlock()
+
+	if deficit > 0 {
+		time.Sleep(time.Duration(deficit / b.ratePerSec * float64(time.Second)))
+	}
+}
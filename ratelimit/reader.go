@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"io"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// defaultChunkSize is used when a caller asks for throttling but doesn't
+// specify a chunk size.
+const defaultChunkSize = 4096
+
+// Reader wraps an io.Reader so that reads are limited to bytesPerSec,
+// delivered in chunks of at most chunkSize bytes with a token-bucket sleep
+// between chunks. A 1 MiB transfer at 10 KiB/s takes about 100s and each
+// chunk boundary is a separate observable read, which is what makes this
+// useful for simulating slow exfiltration and beaconing.
+type Reader struct {
+	r         io.Reader
+	b         *bucket
+	chunkSize int
+	log       *logrus.Entry
+	start     time.Time
+	sent      int64
+}
+
+// NewReader returns a Reader that throttles r to bytesPerSec, in chunks of
+// chunkSize bytes (defaultChunkSize if chunkSize <= 0). Progress is logged
+// at debug level through log.
+func NewReader(r io.Reader, bytesPerSec, chunkSize int, log *logrus.Entry) *Reader {
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+	return &Reader{
+		r:         r,
+		b:         newBucket(bytesPerSec),
+		chunkSize: chunkSize,
+		log:       log,
+		start:     time.Now(),
+	}
+}
+
+// Read reads at most chunkSize bytes from the wrapped reader, blocking
+// beforehand for however long the token bucket requires.
+func (r *Reader) Read(p []byte) (int, error) {
+	if len(p) > r.chunkSize {
+		p = p[:r.chunkSize]
+	}
+	r.b.take(len(p))
+
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.sent += int64(n)
+		r.log.WithFields(logrus.Fields{
+			"bytes sent this chunk": n,
+			"bytes sent total":      r.sent,
+			"elapsed":               time.Since(r.start).String(),
+		}).Debug("rate-limited chunk sent")
+	}
+	return n, err
+}
@@ -0,0 +1,28 @@
+package ratelimit
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReaderThrottles(t *testing.T) {
+	t.Parallel()
+	payload := strings.Repeat("x", 100)
+	r := NewReader(strings.NewReader(payload), 100, 50, logrus.NewEntry(logrus.New()))
+
+	start := time.Now()
+	out, err := io.ReadAll(r)
+	elapsed := time.Since(start)
+
+	assert.NoError(t, err)
+	assert.Equal(t, payload, string(out))
+	// 100 bytes at 100 B/s, delivered in two 50-byte chunks: the bucket
+	// starts full, so both chunks go straight through and only the final
+	// read, which finds EOF, waits about half a second for its tokens.
+	assert.GreaterOrEqual(t, elapsed, 500*time.Millisecond)
+}
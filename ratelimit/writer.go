@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"io"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Writer wraps an io.Writer so that writes are limited to bytesPerSec,
+// accepted in chunks of at most chunkSize bytes with a token-bucket sleep
+// between chunks. It mirrors Reader for the receiving side of a throttled
+// transfer.
+type Writer struct {
+	w         io.Writer
+	b         *bucket
+	chunkSize int
+	log       *logrus.Entry
+	start     time.Time
+	received  int64
+}
+
+// NewWriter returns a Writer that throttles writes to w to bytesPerSec, in
+// chunks of chunkSize bytes (defaultChunkSize if chunkSize <= 0). Progress is
+// logged at debug level through log.
+func NewWriter(w io.Writer, bytesPerSec, chunkSize int, log *logrus.Entry) *Writer {
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+	return &Writer{
+		w:         w,
+		b:         newBucket(bytesPerSec),
+		chunkSize: chunkSize,
+		log:       log,
+		start:     time.Now(),
+	}
+}
+
+// Write writes p to the wrapped writer in chunkSize pieces, blocking between
+// each for however long the token bucket requires.
+func (w *Writer) Write(p []byte) (int, error) {
+	var written int
+	for len(p) > 0 {
+		n := len(p)
+		if n > w.chunkSize {
+			n = w.chunkSize
+		}
+		w.b.take(n)
+
+		m, err := w.w.Write(p[:n])
+		written += m
+		w.received += int64(m)
+		if m > 0 {
+			w.log.WithFields(logrus.Fields{
+				"bytes received this chunk": m,
+				"bytes received total":      w.received,
+				"elapsed":                   time.Since(w.start).String(),
+			}).Debug("rate-limited chunk received")
+		}
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
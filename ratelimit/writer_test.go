@@ -0,0 +1,29 @@
+package ratelimit
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriterThrottles(t *testing.T) {
+	t.Parallel()
+	payload := bytes.Repeat([]byte("x"), 100)
+	var buf bytes.Buffer
+	w := NewWriter(&buf, 50, 50, logrus.NewEntry(logrus.New()))
+
+	start := time.Now()
+	n, err := w.Write(payload)
+	elapsed := time.Since(start)
+
+	assert.NoError(t, err)
+	assert.Equal(t, len(payload), n)
+	assert.Equal(t, payload, buf.Bytes())
+	// 100 bytes at 50 B/s, accepted in two 50-byte chunks, should take
+	// roughly one second: the bucket starts with exactly one chunk's worth
+	// of tokens, so only the second chunk has to wait for a refill.
+	assert.GreaterOrEqual(t, elapsed, 800*time.Millisecond)
+}
@@ -4,90 +4,43 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"github.com/kballard/go-shellquote"
-	"github.com/sirupsen/logrus"
-	"golang.org/x/sync/errgroup"
 	"io"
-	"math/rand"
-	"net"
 	"os"
-	"os/exec"
+	"os/signal"
 	"os/user"
-	"path/filepath"
-	"strconv"
 	"strings"
 	"time"
+
+	"github.com/rwool/red/events"
+	"github.com/rwool/red/network"
+	"github.com/sirupsen/logrus"
 )
 
-// LocalhostTCPConnection creates a TCP connection over localhost and send the
+// LocalhostTCPConnect creates a TCP connection over localhost and sends the
 // data from r to it. The data read on the server side will be written to w.
+// A NetworkEvent describing the transfer is published through emit.
 //
-// This function uses the deadline from ctx to prevent indefinite blocking, if
-// provided.
-func LocalhostTCPConnect(ctx context.Context, log *logrus.Entry, r io.Reader, w io.Writer) (sent int64, e error) {
-	dl, dlOk := ctx.Deadline()
-
-	l, err := net.ListenTCP("tcp", &net.TCPAddr{
-		IP:   net.IPv4(127, 0, 0, 1),
-		Port: 0, // Randomly chosen open port.
+// It is a thin wrapper around network.Connect kept for backward
+// compatibility with existing callers and tests; new code should call
+// network.Connect directly.
+func LocalhostTCPConnect(ctx context.Context, log *logrus.Entry, emit events.Emitter, r io.Reader, w io.Writer) (sent int64, e error) {
+	result, err := network.Connect(ctx, log, network.Spec{
+		Transport: network.TCP,
+		Payload:   r,
+		Received:  w,
 	})
-	if err != nil {
-		return 0, fmt.Errorf("unable to create TCP listener: %w", err)
-	}
-	defer func() {
-		if err := l.Close(); err != nil {
-			log.WithError(err).Error("close TCP listener")
-		}
-	}()
-	if dlOk {
-		if err := l.SetDeadline(dl); err != nil {
-			return 0, fmt.Errorf("unable to set listener deadline: %w", err)
-		}
+	if emitErr := emit.Emit(events.NetworkEvent{
+		Event:         events.NewEvent(techniqueIngressToolTransfer),
+		SourceIP:      "127.0.0.1",
+		DestinationIP: "127.0.0.1",
+		Transport:     "tcp",
+		BytesSent:     result.BytesSent,
+		User:          currentUsername(),
+	}); emitErr != nil {
+		log.WithError(emitErr).Error("emit network event")
 	}
-
-	var g, _ = errgroup.WithContext(ctx)
-	g.Go(func() error {
-		conn, err := l.Accept()
-		if err != nil {
-			return fmt.Errorf("error accepting connection: %w", err)
-		}
-		if dlOk {
-			if err := conn.SetReadDeadline(dl); err != nil {
-				return fmt.Errorf("unable to set server connection deadline: %w", err)
-			}
-		}
-		_, err = io.Copy(w, conn)
-		if err != nil {
-			return fmt.Errorf("error copying data from server connection: %w", err)
-		}
-		return nil
-	})
-
-	var d net.Dialer
-	conn, err := d.DialContext(ctx, "tcp", l.Addr().String())
-	if err != nil {
-		return 0, fmt.Errorf("unable to connect to loopback TCP listener: %w", err)
-	}
-
-	sent, err = io.Copy(conn, r)
-	log = log.WithFields(logrus.Fields{
-		"destination address": conn.RemoteAddr().String(),
-		"source address":      conn.LocalAddr().String(),
-		"protocol":            conn.LocalAddr().Network(),
-		"data sent (bytes)":   sent,
-	})
 	errLog(log, err, "data transmission")
-	if err != nil {
-		return sent, fmt.Errorf("error copying data to client connection: %w", err)
-	}
-	if err := conn.Close(); err != nil {
-		return sent, fmt.Errorf("error closing client connection: %w", err)
-	}
-
-	if err := g.Wait(); err != nil {
-		return sent, fmt.Errorf("server listener error: %w", err)
-	}
-	return sent, nil
+	return result.BytesSent, err
 }
 
 func errLog(l *logrus.Entry, e error, msg string) {
@@ -98,9 +51,9 @@ func errLog(l *logrus.Entry, e error, msg string) {
 	l.Info(msg)
 }
 
-// RunActivities runs five different activities to trigger events with the EDR
-// agent.
-func RunActivities(ctx context.Context, l *logrus.Logger, directory, fileExt string, arguments []string) error {
+// RunActivities builds the activities described by cfg and runs each in turn
+// (Prepare, then Run, then Cleanup), stopping at the first error.
+func RunActivities(ctx context.Context, l *logrus.Logger, emit events.Emitter, cfg *Config) error {
 	u, err := user.Current()
 	if err != nil {
 		return fmt.Errorf("unable to get user information: %w", err)
@@ -111,84 +64,54 @@ func RunActivities(ctx context.Context, l *logrus.Logger, directory, fileExt str
 		"process command line": strings.Join(os.Args, " "),
 		"process ID":           os.Getpid(),
 	})
-
-	// Run process.
-	cmd := exec.CommandContext(ctx, "echo", arguments...)
-	err = cmd.Start()
-	errLog(log, err, "process start")
-	if err != nil {
-		return fmt.Errorf("error starting the command: %w", err)
-	}
-	if err := cmd.Wait(); err != nil {
-		return fmt.Errorf("command error: %w", err)
+	if err := emit.Emit(events.AuthEvent{
+		Event:   events.NewEvent(""),
+		User:    u.Username,
+		Outcome: "success",
+	}); err != nil {
+		log.WithError(err).Error("emit auth event")
 	}
 
-	// Create file.
-	if directory == "" {
-		directory = os.TempDir()
-	}
-	directory, err = filepath.Abs(directory)
-	if err != nil {
-		return fmt.Errorf("unable to get absolute path for directory: %w", err)
+	for _, ac := range cfg.Activities {
+		if err := runOnce(ctx, log, emit, ac); err != nil {
+			return err
+		}
 	}
-	r := rand.New(rand.NewSource(time.Now().Unix()))
-	path := filepath.Join(directory, strconv.Itoa(r.Intn(1_000_000))+fileExt)
-	fLog := log.WithFields(logrus.Fields{
-		"file path":     path,
-		"file activity": "create",
-	})
-	f, err := os.Create(path)
-	errLog(fLog, err, "create file")
+
+	return nil
+}
+
+// runOnce constructs the activity described by ac and takes it through
+// Prepare, Run, and Cleanup, in that order. Cleanup runs even if Prepare or
+// Run failed.
+func runOnce(ctx context.Context, log *logrus.Entry, emit events.Emitter, ac ActivityConfig) (e error) {
+	aLog := log.WithField("activity", ac.Name)
+	a, err := NewActivity(aLog, emit, ac.Name, ac.Params)
 	if err != nil {
-		return fmt.Errorf("unable to create file: %w", err)
+		return fmt.Errorf("unable to construct activity %q: %w", ac.Name, err)
 	}
 
-	// Run code in closure with defer to ensure attempt is made to close and
-	// delete file before moving on to the network transmission activity.
-	err = func() (e error) {
-		defer func() {
-			// Close file.
-			if err := f.Close(); err != nil {
-				log.WithError(err).Error("close file")
-			}
-
-			// Delete file.
-			err = os.Remove(f.Name())
-			errLog(fLog.WithField("file activity", "delete"), err, "delete file")
-			if err != nil && e == nil {
-				e = fmt.Errorf("unable to delete file: %w", err)
+	defer func() {
+		if err := a.Cleanup(ctx); err != nil {
+			errLog(aLog, err, "activity cleanup")
+			if e == nil {
+				e = fmt.Errorf("activity %q cleanup error: %w", ac.Name, err)
 			}
-		}()
-
-		// Modify file.
-		_, err = f.WriteString("file append")
-		errLog(fLog.WithField("file activity", "modify"), err, "modify file")
-		if err != nil {
-			return fmt.Errorf("unable to write to string: %w", err)
 		}
-		return nil
 	}()
-	if err != nil {
-		return err
-	}
 
-	// Network connection and data transmission.
-	_, err = LocalhostTCPConnect(ctx, log, strings.NewReader("hello"), io.Discard)
-	if err != nil {
-		return fmt.Errorf("unable to transmit data over a network connection: %w", err)
+	if err := a.Prepare(ctx); err != nil {
+		return fmt.Errorf("activity %q prepare error: %w", ac.Name, err)
+	}
+	if err := a.Run(ctx); err != nil {
+		return fmt.Errorf("activity %q run error: %w", ac.Name, err)
 	}
-
 	return nil
 }
 
-func main() {
-	// Get the command line arguments.
-	dir := flag.String("file-directory", "", "directory to create file (defaults to OS temporary directory)")
-	ext := flag.String("file-extension", ".txt", "extension for file")
-	args := flag.String("process-arguments", "", "arguments for program to run (shell quoted)")
-	flag.Parse()
-
-	// Use structured JSON for the output format.
+// newJSONLogger returns a logrus.Logger configured to emit structured JSON,
+// shared by the normal activity-running path and the relay subcommand.
+func newJSONLogger() *logrus.Logger {
 	l := logrus.New()
 	l.Formatter = &logrus.JSONFormatter{
 		TimestampFormat: time.RFC3339Nano,
@@ -196,18 +119,82 @@ func main() {
 			logrus.FieldKeyTime: "timestamp",
 		},
 	}
+	return l
+}
 
-	// Parse out the process arguments like a POSIX shell.
-	pArgs, err := shellquote.Split(*args)
-	if err != nil {
-		l.Fatalf("Invalid process arguments: %v", err)
+func main() {
+	// `red relay` turns the binary into a rendezvous relay instead of
+	// running activities; it has its own small flag set.
+	if len(os.Args) > 1 && os.Args[1] == "relay" {
+		relayFlags := flag.NewFlagSet("relay", flag.ExitOnError)
+		listen := relayFlags.String("listen", ":4444", "address for the relay to listen on")
+		relayFlags.Parse(os.Args[2:])
+
+		l := newJSONLogger()
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		if err := RunRelay(ctx, l, *listen); err != nil {
+			l.Fatalf("Error running relay: %v", err)
+		}
+		return
+	}
+
+	// Get the command line arguments.
+	configPath := flag.String("config", "", "path to a YAML/JSON activity config file (overrides the file, process, relay and room flags below)")
+	dir := flag.String("file-directory", "", "directory to create file (defaults to OS temporary directory)")
+	ext := flag.String("file-extension", ".txt", "extension for file")
+	args := flag.String("process-arguments", "", "arguments for program to run (shell quoted)")
+	eventFormat := flag.String("event-format", "logrus", `event emitter backend: "logrus" or "ndjson"`)
+	duration := flag.Duration("duration", 0, "run as a scheduled daemon for this long, honoring each activity's schedule (0 = run every activity once and exit)")
+	relayAddr := flag.String("relay", "", "address of a `red relay` to route the network activity through, instead of its loopback listener")
+	room := flag.String("room", "", "room code to pair up with a peer through --relay")
+	flag.Parse()
+
+	// Use structured JSON for the output format.
+	l := newJSONLogger()
+
+	// Load the activity config, falling back to a config synthesized from the
+	// legacy flags so existing invocations keep working.
+	var cfg *Config
+	if *configPath != "" {
+		var err error
+		cfg, err = LoadConfig(*configPath)
+		if err != nil {
+			l.Fatalf("Error loading config: %v", err)
+		}
+	} else {
+		cfg = withRelay(defaultConfig(*dir, *ext, *args), *relayAddr, *room)
+	}
+
+	var emit events.Emitter
+	switch *eventFormat {
+	case "logrus":
+		emit = events.NewLogrusEmitter(logrus.NewEntry(l))
+	case "ndjson":
+		emit = events.NewNDJSONEmitter(os.Stdout)
+	default:
+		l.Fatalf("Unknown event format %q", *eventFormat)
+	}
+
+	if *duration > 0 {
+		// Run as a daemon until duration elapses or SIGINT requests a
+		// graceful shutdown, in which case we wait for in-flight activities
+		// instead of killing them.
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		ctx, cancel := context.WithTimeout(ctx, *duration)
+		defer cancel()
+		if err := RunSchedule(ctx, l, emit, cfg); err != nil {
+			l.Fatalf("Error running scheduled activities: %v", err)
+		}
+		return
 	}
 
-	// Run the activities with a timeout to hopefully stop the program in the
-	// event of unexpectedly long blocking call.
+	// Run the activities once, with a timeout to hopefully stop the program
+	// in the event of unexpectedly long blocking call.
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	if err := RunActivities(ctx, l, *dir, *ext, pArgs); err != nil {
+	if err := RunActivities(ctx, l, emit, cfg); err != nil {
 		l.Fatalf("Error running activities: %v", err)
 	}
 }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"github.com/rwool/red/events"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"strings"
@@ -41,9 +42,11 @@ func TestLocalhostTCPConnect(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	var buf strings.Builder
+	log := newLogger(t).WithField("test", t.Name())
 	sent, err := LocalhostTCPConnect(
 		ctx,
-		newLogger(t).WithField("test", t.Name()),
+		log,
+		events.NewLogrusEmitter(log),
 		strings.NewReader("hello"),
 		&buf,
 	)
@@ -56,6 +59,7 @@ func TestRunActivities(t *testing.T) {
 	t.Parallel()
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	err := RunActivities(ctx, newLogger(t), "", ".txt", nil)
+	log := newLogger(t).WithField("test", t.Name())
+	err := RunActivities(ctx, newLogger(t), events.NewLogrusEmitter(log), defaultConfig("", ".txt", ""))
 	assert.NoError(t, err)
 }
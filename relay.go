@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// relayRoomCodeMaxLen bounds how much a room code line can be, so a
+// misbehaving client can't make the relay buffer unbounded data waiting for
+// a newline.
+const relayRoomCodeMaxLen = 256
+
+// RunRelay runs the `relay` subcommand: a TCP rendezvous relay listening on
+// addr. Each connecting client sends a newline-terminated room code as its
+// first line; once two clients have sent the same code, the relay pipes
+// bytes bidirectionally between them until both directions have closed.
+//
+// This lets a "sender" host and a "receiver" host exchange a payload through
+// a shared relay instead of over loopback, so each host's own network
+// activity generates independently observable telemetry.
+func RunRelay(ctx context.Context, l *logrus.Logger, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("unable to listen on %q: %w", addr, err)
+	}
+	log := l.WithField("component", "relay")
+	log.WithField("address", ln.Addr().String()).Info("relay listening")
+
+	go func() {
+		<-ctx.Done()
+		if err := ln.Close(); err != nil {
+			log.WithError(err).Error("close relay listener")
+		}
+	}()
+
+	var mu sync.Mutex
+	waiting := map[string]net.Conn{}
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			select {
+			case <-ctx.Done():
+				return nil
+			default:
+				return fmt.Errorf("error accepting connection: %w", err)
+			}
+		}
+		go acceptRelayClient(log, &mu, waiting, conn)
+	}
+}
+
+// acceptRelayClient reads conn's room code, then either parks it in waiting
+// until a peer with the same room code arrives, or pairs it with one already
+// waiting and starts relaying.
+func acceptRelayClient(log *logrus.Entry, mu *sync.Mutex, waiting map[string]net.Conn, conn net.Conn) {
+	room, buffered, err := readRoomCode(conn)
+	if err != nil {
+		log.WithError(err).Error("read room code")
+		if err := conn.Close(); err != nil {
+			log.WithError(err).Error("close relay client connection")
+		}
+		return
+	}
+	// Any bytes the client wrote immediately after the room code line may
+	// already be sitting in buffered's internal buffer; keep using buffered
+	// instead of conn for reads so those bytes aren't dropped.
+	rConn := &relayConn{Conn: conn, r: buffered}
+	rLog := log.WithFields(logrus.Fields{
+		"room":         room,
+		"peer address": conn.RemoteAddr().String(),
+	})
+
+	mu.Lock()
+	peer, ok := waiting[room]
+	if !ok {
+		waiting[room] = rConn
+		mu.Unlock()
+		rLog.Info("relay: waiting for second peer")
+		return
+	}
+	delete(waiting, room)
+	mu.Unlock()
+
+	rLog.Info("relay: peer found, relaying")
+	relayPipe(rLog, rConn, peer)
+}
+
+// relayConn wraps a net.Conn so reads are served from r, which may already
+// hold buffered bytes read while looking for the room-code line's newline.
+type relayConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *relayConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+// readRoomCode reads conn's first line and returns it with the trailing
+// newline removed, along with the *bufio.Reader it was read through so the
+// caller can keep consuming any bytes already buffered past the newline.
+func readRoomCode(conn net.Conn) (string, *bufio.Reader, error) {
+	r := bufio.NewReaderSize(conn, relayRoomCodeMaxLen)
+	// ReadSlice fails with bufio.ErrBufferFull instead of growing past
+	// relayRoomCodeMaxLen when no newline arrives.
+	line, err := r.ReadSlice('\n')
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to read room code: %w", err)
+	}
+	return strings.TrimRight(string(line), "\r\n"), r, nil
+}
+
+// relayPipe copies bytes bidirectionally between a and b until both
+// directions have closed.
+func relayPipe(log *logrus.Entry, a, b net.Conn) {
+	defer a.Close()
+	defer b.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	pipe := func(dst io.Writer, src io.Reader) {
+		defer wg.Done()
+		if _, err := io.Copy(dst, src); err != nil {
+			log.WithError(err).Debug("relay: pipe closed")
+		}
+	}
+	go pipe(a, b)
+	go pipe(b, a)
+	wg.Wait()
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunRelayPairsClientsByRoomCode(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	log := newLogger(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	go func() {
+		_ = RunRelay(ctx, log, addr)
+	}()
+
+	dial := func() net.Conn {
+		var conn net.Conn
+		var err error
+		for i := 0; i < 50; i++ {
+			conn, err = net.Dial("tcp", addr)
+			if err == nil {
+				return conn
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		require.NoError(t, err)
+		return conn
+	}
+
+	a := dial()
+	defer a.Close()
+	b := dial()
+	defer b.Close()
+
+	_, err = a.Write([]byte("room-1\nping"))
+	require.NoError(t, err)
+	_, err = b.Write([]byte("room-1\n"))
+	require.NoError(t, err)
+
+	buf := make([]byte, len("ping"))
+	require.NoError(t, b.SetReadDeadline(time.Now().Add(2*time.Second)))
+	_, err = b.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(buf))
+}
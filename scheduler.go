@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rwool/red/events"
+	"github.com/sirupsen/logrus"
+)
+
+// RunSchedule runs cfg as a long-lived daemon instead of a single pass:
+// activities with a Schedule repeat on their own ticker, with jitter, up to
+// Count times (or until ctx is done, if Count is 0), bounded by a
+// per-activity worker pool sized by Concurrency. Activities without a
+// Schedule all run once, in cfg.Activities order, on a single goroutine
+// shared across them — the same in-order guarantee RunActivities gives —
+// running concurrently alongside whatever scheduled activities are ticking.
+//
+// RunSchedule returns once every activity has finished all of its runs, or
+// once ctx is done and every in-flight activity (started before ctx was
+// done) has finished, so a canceled ctx produces a graceful shutdown rather
+// than an abrupt one.
+func RunSchedule(ctx context.Context, l *logrus.Logger, emit events.Emitter, cfg *Config) error {
+	u, err := user.Current()
+	if err != nil {
+		return err
+	}
+	log := l.WithFields(logrus.Fields{
+		"username":             u.Username,
+		"process name":         os.Args[0],
+		"process command line": strings.Join(os.Args, " "),
+		"process ID":           os.Getpid(),
+	})
+	if err := emit.Emit(events.AuthEvent{
+		Event:   events.NewEvent(""),
+		User:    u.Username,
+		Outcome: "success",
+	}); err != nil {
+		log.WithError(err).Error("emit auth event")
+	}
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	recordErr := func(err error) {
+		errOnce.Do(func() { firstErr = err })
+	}
+
+	var unscheduled []ActivityConfig
+	for _, ac := range cfg.Activities {
+		ac := ac
+		if ac.Schedule == nil {
+			unscheduled = append(unscheduled, ac)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runScheduled(ctx, log, emit, ac, recordErr)
+		}()
+	}
+
+	if len(unscheduled) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, ac := range unscheduled {
+				if err := runOnce(ctx, log, emit, ac); err != nil {
+					recordErr(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	return firstErr
+}
+
+// runScheduled repeats ac on its Schedule until ctx is done or Schedule.Count
+// repetitions have run (if Count > 0), waiting for any still-running
+// repetitions before returning.
+func runScheduled(ctx context.Context, log *logrus.Entry, emit events.Emitter, ac ActivityConfig, recordErr func(error)) {
+	concurrency := ac.Schedule.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	for count := 0; ac.Schedule.Count <= 0 || count < ac.Schedule.Count; count++ {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case <-time.After(nextDelay(ac.Schedule)):
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := runOnce(ctx, log, emit, ac); err != nil {
+				recordErr(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// nextDelay returns s.Interval plus a random amount of jitter in
+// [0, s.Jitter).
+func nextDelay(s *Schedule) time.Duration {
+	delay := time.Duration(s.Interval)
+	if jitter := time.Duration(s.Jitter); jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(jitter)))
+	}
+	return delay
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rwool/red/events"
+	"github.com/rwool/red/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunScheduleRunsEachActivityOnce(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	log := newLogger(t).WithField("test", t.Name())
+	cfg := defaultConfig("", ".txt", "")
+	err := RunSchedule(ctx, newLogger(t), events.NewLogrusEmitter(log), cfg)
+	assert.NoError(t, err)
+}
+
+// TestRunScheduleRunsUnscheduledActivitiesInOrder asserts that, with no
+// Schedule set on any activity, RunSchedule preserves the same in-order
+// guarantee RunActivities gives: process, then file, then network, never
+// interleaved.
+func TestRunScheduleRunsUnscheduledActivitiesInOrder(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var buf bytes.Buffer
+	cfg := defaultConfig("", ".txt", "")
+	err := RunSchedule(ctx, newLogger(t), events.NewNDJSONEmitter(&buf), cfg)
+	require.NoError(t, err)
+
+	var techniques []string
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var e struct {
+			Technique string `json:"threat.technique.id"`
+		}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		if e.Technique != "" {
+			techniques = append(techniques, e.Technique)
+		}
+	}
+	require.NoError(t, scanner.Err())
+
+	firstIndex := func(technique string) int {
+		for i, tq := range techniques {
+			if tq == technique {
+				return i
+			}
+		}
+		return -1
+	}
+	processAt := firstIndex(techniqueProcessExecution)
+	fileAt := firstIndex(techniqueFileOperation)
+	networkAt := firstIndex(networkTechniques[network.TCP])
+	require.NotEqual(t, -1, processAt)
+	require.NotEqual(t, -1, fileAt)
+	require.NotEqual(t, -1, networkAt)
+	assert.True(t, processAt < fileAt && fileAt < networkAt, "expected process < file < network, got %v", techniques)
+}
+
+func TestRunScheduleRepeatsWithCount(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	log := newLogger(t).WithField("test", t.Name())
+	cfg := &Config{
+		Activities: []ActivityConfig{
+			{
+				Name:     "process",
+				Params:   map[string]interface{}{"arguments": ""},
+				Schedule: &Schedule{Interval: Duration(10 * time.Millisecond), Count: 3},
+			},
+		},
+	}
+	err := RunSchedule(ctx, newLogger(t), events.NewLogrusEmitter(log), cfg)
+	assert.NoError(t, err)
+}